@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -13,10 +14,17 @@ import (
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
+
+	"github.com/EliasMaDeRe/UCPTest/internal/actions"
+	"github.com/EliasMaDeRe/UCPTest/internal/checks"
+	"github.com/EliasMaDeRe/UCPTest/internal/config"
+	"github.com/EliasMaDeRe/UCPTest/internal/diffpos"
+	"github.com/EliasMaDeRe/UCPTest/internal/fileset"
+	"github.com/EliasMaDeRe/UCPTest/internal/retries"
 )
 
 const (
-	homeworkInstructionsFile = "homework0e3.txt" 
+	homeworkInstructionsFile = "homework0e3.txt"
 	geminiPromptTemplate     = `You are an AI assistant specialized in evaluating code against homework instructions.
 Your task is to analyze the provided code snippets (which may be in various programming languages) and determine if they correctly implement the requirements described in the homework instructions.
 Focus on correctness, completeness, and adherence to the problem statement. Do not focus on style unless explicitly mentioned in the instructions.
@@ -31,10 +39,15 @@ Provided Code Files:
 %s
 ---
 
-Based on the above, please provide a concise evaluation.
-If the code is correct and complete according to the instructions, state "APPROVED" and provide a brief justification.
-If there are issues, state "REJECTED" and explain clearly what needs to be fixed or improved.
-Be specific and actionable in your feedback, referencing specific parts of the code or instructions if necessary.
+Based on the above, evaluate the submission and respond with ONLY a single JSON object of the form:
+{
+  "approved": true or false,
+  "summary": "one or two sentence overall verdict",
+  "findings": [
+    {"file": "path/as/shown/in/the/file/header", "line": 1, "severity": "error|warning|notice", "message": "specific, actionable feedback"}
+  ]
+}
+Omit "findings" or leave it empty if the submission is fully correct. Reference real line numbers from the provided files. Do not wrap the JSON in markdown fences.
 `
 )
 
@@ -62,8 +75,345 @@ type GitHubCommitDetails struct {
 	} `json:"files"`
 }
 
+// Finding is a single Gemini-authored piece of feedback tied to a file and
+// line, as required by the JSON response format in geminiPromptTemplate.
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// GeminiVerdict is the structured evaluation returned by the grading prompt.
+type GeminiVerdict struct {
+	Approved bool      `json:"approved"`
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+func (f Finding) severity() actions.Severity {
+	switch strings.ToLower(f.Severity) {
+	case "warning":
+		return actions.SeverityWarning
+	case "notice":
+		return actions.SeverityNotice
+	default:
+		return actions.SeverityError
+	}
+}
+
+func (f Finding) annotationLevel() checks.AnnotationLevel {
+	switch strings.ToLower(f.Severity) {
+	case "warning":
+		return checks.AnnotationWarning
+	case "notice":
+		return checks.AnnotationNotice
+	default:
+		return checks.AnnotationFailure
+	}
+}
+
+// truncateDescription trims s to the 140-character limit the Statuses API
+// enforces on the description field.
+func truncateDescription(s string) string {
+	const maxLen = 140
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-1] + "…"
+}
+
+// pullRequestFile is the subset of the "list pull request files" API response
+// needed to map Gemini's absolute line numbers onto the diff.
+type pullRequestFile struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"`
+}
+
+// reviewComment is a single inline comment attached to a pull request
+// review, using the current `line`/`side: RIGHT` form the Reviews API
+// expects.
+type reviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line,omitempty"`
+	Side string `json:"side,omitempty"`
+	Body string `json:"body"`
+}
+
+type reviewRequest struct {
+	CommitID string          `json:"commit_id"`
+	Body     string          `json:"body"`
+	Event    string          `json:"event"`
+	Comments []reviewComment `json:"comments"`
+}
+
+// resolvePullRequest looks up the open pull request (if any) associated with
+// headCommitSHA via GET /repos/{owner}/{repo}/commits/{sha}/pulls. It returns
+// 0 if the push isn't associated with any open pull request.
+func resolvePullRequest(client *http.Client, owner, repo, sha, token string) (int, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s/pulls", owner, repo, sha), nil)
+	if err != nil {
+		return 0, err
+	}
+	// This endpoint is only exposed under the "groot" preview media type on
+	// GitHub Enterprise Server; github.com has long since GA'd it, but we set
+	// both Accept values to be safe.
+	req.Header.Set("Accept", "application/vnd.github.groot-preview+json, application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return 0, fmt.Errorf("GET commit pulls failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pulls []struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
+		return 0, err
+	}
+	for _, pr := range pulls {
+		if pr.State == "open" {
+			return pr.Number, nil
+		}
+	}
+	return 0, nil
+}
+
+// fetchPullRequestFiles returns the patches for every file in pull request
+// number, used to check which findings' lines are part of the diff. The
+// positions diffpos computes from them are not posted anywhere: no comment
+// is sent through the position-based review-comments endpoint.
+func fetchPullRequestFiles(client *http.Client, owner, repo string, number int, token string) ([]pullRequestFile, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/files", owner, repo, number), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GET pull request files failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var files []pullRequestFile
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// buildReviewComments converts Gemini findings into review comments, mapping
+// each to the `line`/`side: RIGHT` form the current reviews API expects.
+// Gemini is prompted with whole-file line numbers, not diff-relative ones, so
+// a finding on a line the diff never touched is the common case, not the
+// exception; the Reviews API rejects the *entire* review if even one
+// comment's line isn't part of the diff, so diffpos is used here purely to
+// validate that a finding's line is actually part of the diff. Findings that
+// don't map to a diff position are dropped from the inline comments (they're
+// still surfaced via the workflow-command and check-run annotations). skipped
+// reports how many findings were dropped this way.
+func buildReviewComments(findings []Finding, files []pullRequestFile) (comments []reviewComment, skipped int) {
+	patchByFile := make(map[string]string, len(files))
+	for _, f := range files {
+		patchByFile[f.Filename] = f.Patch
+	}
+
+	for _, finding := range findings {
+		patch, ok := patchByFile[finding.File]
+		if !ok {
+			skipped++
+			continue
+		}
+		mapper, err := diffpos.NewMapper(patch)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if _, ok := mapper.Position(finding.Line); !ok {
+			skipped++
+			continue
+		}
+		comments = append(comments, reviewComment{
+			Path: finding.File,
+			Line: finding.Line,
+			Side: "RIGHT",
+			Body: finding.Message,
+		})
+	}
+	return comments, skipped
+}
+
+// writeStepSummary writes summary to the GITHUB_STEP_SUMMARY file, retrying
+// transient failures. It's the fallback path for feedback that couldn't be
+// delivered as a pull request review.
+func writeStepSummary(ctx context.Context, summary string) {
+	summaryFilePath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFilePath == "" {
+		log.Println("GITHUB_STEP_SUMMARY not found. Outputting feedback to stdout.")
+		return
+	}
+	writeErr := retries.Wait(ctx, func() error {
+		return ioutil.WriteFile(summaryFilePath, []byte(summary), 0644)
+	}, retries.DefaultPolicy())
+	if writeErr != nil {
+		log.Printf("Warning: Failed to write to GITHUB_STEP_SUMMARY: %v", writeErr)
+	}
+}
+
+// postPullRequestReview submits a single pull request review covering every
+// finding, approving when the submission was approved and requesting changes
+// otherwise. It returns the number of findings that could not be attached as
+// inline comments because their line isn't part of the diff.
+func postPullRequestReview(client *http.Client, owner, repo string, number int, token string, commitSHA string, verdict GeminiVerdict, files []pullRequestFile) (skipped int, err error) {
+	event := "REQUEST_CHANGES"
+	if verdict.Approved {
+		event = "APPROVE"
+	}
+
+	comments, skipped := buildReviewComments(verdict.Findings, files)
+	body, err := json.Marshal(reviewRequest{
+		CommitID: commitSHA,
+		Body:     verdict.Summary,
+		Event:    event,
+		Comments: comments,
+	})
+	if err != nil {
+		return skipped, err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/reviews", owner, repo, number), strings.NewReader(string(body)))
+	if err != nil {
+		return skipped, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return skipped, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return skipped, fmt.Errorf("POST pull request review failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return skipped, nil
+}
+
+// assignmentGroup collects the changed files routed to one Assignment (or
+// the implicit single-assignment default when no grader.yaml is present).
+type assignmentGroup struct {
+	assignment    config.Assignment
+	displayPaths  []string
+	adjustedPaths []string
+}
+
+// gradeAssignment runs one Gemini evaluation for the files in g, reading
+// g.assignment.InstructionsFile (falling back to homeworkInstructionsFile
+// when unset), appending g.assignment.RubricFile's contents if set, and
+// returning the structured verdict.
+func gradeAssignment(ctx context.Context, act *actions.Writer, model *genai.GenerativeModel, repoRootPrefix string, g assignmentGroup) (GeminiVerdict, error) {
+	instructionsFile := g.assignment.InstructionsFile
+	if instructionsFile == "" {
+		instructionsFile = homeworkInstructionsFile
+	}
+	actualInstructionsFile := filepath.Join(repoRootPrefix, instructionsFile)
+	instructions, err := ioutil.ReadFile(actualInstructionsFile)
+	if err != nil {
+		return GeminiVerdict{}, fmt.Errorf("reading instructions file '%s': %w", actualInstructionsFile, err)
+	}
+	instructionsContent := string(instructions)
+	if g.assignment.RubricFile != "" {
+		rubricPath := filepath.Join(repoRootPrefix, g.assignment.RubricFile)
+		rubric, err := ioutil.ReadFile(rubricPath)
+		if err != nil {
+			log.Printf("Warning: Could not read rubric file '%s': %v", rubricPath, err)
+		} else {
+			instructionsContent += "\n\n---\nGrading Rubric:\n" + string(rubric) + "\n---"
+		}
+	}
+
+	var allCodeContent strings.Builder
+	for i, adjustedPath := range g.adjustedPaths {
+		code, err := ioutil.ReadFile(adjustedPath)
+		if err != nil {
+			log.Printf("Warning: Could not read file '%s': %v", adjustedPath, err)
+			continue
+		}
+		allCodeContent.WriteString(fmt.Sprintf("--- File: %s ---\n", g.displayPaths[i]))
+		allCodeContent.Write(code)
+		allCodeContent.WriteString("\n\n")
+	}
+	if allCodeContent.Len() == 0 {
+		return GeminiVerdict{}, errors.New("no code could be read from the changed files for this assignment")
+	}
+
+	act.Group("prompt")
+	prompt := fmt.Sprintf(geminiPromptTemplate, instructionsContent, allCodeContent.String())
+	var resp *genai.GenerateContentResponse
+	err = retries.Wait(ctx, func() error {
+		var genErr error
+		resp, genErr = model.GenerateContent(ctx, genai.Text(prompt))
+		return genErr
+	}, retries.DefaultPolicy())
+	act.EndGroup()
+	if err != nil {
+		return GeminiVerdict{}, fmt.Errorf("generating content from Gemini: %w", err)
+	}
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return GeminiVerdict{}, errors.New("Gemini did not return any content for the evaluation")
+	}
+
+	var geminiOutput strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			geminiOutput.WriteString(string(txt))
+		}
+	}
+	rawFeedback := strings.TrimSpace(geminiOutput.String())
+	rawFeedback = strings.TrimPrefix(rawFeedback, "```json")
+	rawFeedback = strings.TrimPrefix(rawFeedback, "```")
+	rawFeedback = strings.TrimSuffix(rawFeedback, "```")
+
+	var verdict GeminiVerdict
+	if err := json.Unmarshal([]byte(rawFeedback), &verdict); err != nil {
+		return GeminiVerdict{}, fmt.Errorf("parsing Gemini response as JSON findings: %w\nRaw response:\n%s", err, rawFeedback)
+	}
+	return verdict, nil
+}
+
 func main() {
-	// 1. Read the GITHUB_EVENT_PATH payload
+	act := actions.Default
+	ctx := context.Background()
+
+	// Mask secrets before anything else is written to stdout.
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	act.AddMask(githubToken)
+	act.AddMask(apiKey)
+
+	if githubToken == "" {
+		log.Fatalf("GITHUB_TOKEN environment variable not set. It is required for GitHub API calls. Ensure your workflow has 'permissions: contents: read'.")
+	}
+	if apiKey == "" {
+		log.Fatalf("GEMINI_API_KEY environment variable not set. Please add it as a GitHub Secret.")
+	}
+
+	act.Group("fetch")
 	githubEventPath := os.Getenv("GITHUB_EVENT_PATH")
 	if githubEventPath == "" {
 		log.Fatalf("GITHUB_EVENT_PATH environment variable not set. This script should run in a GitHub Actions workflow.")
@@ -79,7 +429,6 @@ func main() {
 		log.Fatalf("Failed to unmarshal GitHub push event payload: %v", err)
 	}
 
-	// --- START: Fetch file changes via GitHub API ---
 	headCommitSHA := pushEvent.HeadCommit.ID
 	repoOwner := pushEvent.Repository.Owner.Login
 	repoName := pushEvent.Repository.Name
@@ -90,11 +439,6 @@ func main() {
 
 	fmt.Printf("Fetching commit details for %s/%s@%s via GitHub API...\n", repoOwner, repoName, headCommitSHA)
 
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		log.Fatalf("GITHUB_TOKEN environment variable not set. It is required for GitHub API calls. Ensure your workflow has 'permissions: contents: read'.")
-	}
-
 	httpClient := &http.Client{}
 	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", repoOwner, repoName, headCommitSHA), nil)
 	if err != nil {
@@ -102,148 +446,210 @@ func main() {
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Authorization", "token "+githubToken)
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28") 
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	respAPI, err := httpClient.Do(req)
+	var apiResponseBody []byte
+	err = retries.Wait(ctx, func() error {
+		respAPI, doErr := httpClient.Do(req)
+		if doErr != nil {
+			return doErr
+		}
+		defer respAPI.Body.Close()
+		body, readErr := ioutil.ReadAll(respAPI.Body)
+		if readErr != nil {
+			return readErr
+		}
+		if respAPI.StatusCode != http.StatusOK {
+			return retries.NewHTTPError(respAPI, fmt.Errorf("GitHub API request failed with status %d for commit %s: %s", respAPI.StatusCode, headCommitSHA, string(body)))
+		}
+		apiResponseBody = body
+		return nil
+	}, retries.DefaultPolicy())
 	if err != nil {
-		log.Fatalf("Error making GitHub API request: %v", err)
-	}
-	defer respAPI.Body.Close()
-
-	if respAPI.StatusCode != http.StatusOK {
-		bodyBytes, _ := ioutil.ReadAll(respAPI.Body)
-		log.Fatalf("GitHub API request failed with status %d for commit %s: %s", respAPI.StatusCode, headCommitSHA, string(bodyBytes))
+		log.Fatalf("Error fetching commit details: %v", err)
 	}
 
 	var commitDetails GitHubCommitDetails
-	apiResponseBody, err := ioutil.ReadAll(respAPI.Body)
-	if err != nil {
-		log.Fatalf("Error reading GitHub API response body: %v", err)
-	}
 	if err := json.Unmarshal(apiResponseBody, &commitDetails); err != nil {
 		log.Fatalf("Error unmarshaling GitHub API response: %v", err)
 	}
 
-	var changedFilesRepoRelative []string 
+	var changedFilesRepoRelative []string
 	for _, file := range commitDetails.Files {
 		// Only consider "added" or "modified" files for grading
 		if file.Status == "added" || file.Status == "modified" {
 			changedFilesRepoRelative = append(changedFilesRepoRelative, file.Filename)
 		}
 	}
-	// --- END: Fetch file changes via GitHub API ---
+	act.EndGroup()
 
+	act.Group("filter")
 	repoRootPrefix := ".." + string(filepath.Separator)
 
-	filteredFiles := []string{}
+	gradingConfig, err := config.Load(filepath.Join(repoRootPrefix))
+	if err != nil {
+		log.Fatalf("Error loading grader.yaml: %v", err)
+	}
+	ignoredFiles := []string{homeworkInstructionsFile, "grader.yaml", ".grader.yml"}
+	if gradingConfig != nil {
+		for _, a := range gradingConfig.Assignments {
+			if a.InstructionsFile != "" {
+				ignoredFiles = append(ignoredFiles, a.InstructionsFile)
+			}
+			if a.RubricFile != "" {
+				ignoredFiles = append(ignoredFiles, a.RubricFile)
+			}
+		}
+	}
+	ignored, err := fileset.Load(repoRootPrefix, ignoredFiles)
+	if err != nil {
+		log.Fatalf("Error loading .graderignore: %v", err)
+	}
+
+	groups := map[string]*assignmentGroup{}
+	var groupOrder []string
 	for _, fileRepoRelative := range changedFilesRepoRelative {
-		// Skip internal grader files (paths will be like 'correctness-tester/main.go' from API)
-		// We use strings.HasPrefix for folder names, and exact match for files like homework.txt
-		if strings.HasPrefix(fileRepoRelative, "correctness-tester/") ||
-			strings.HasPrefix(fileRepoRelative, ".github/workflows/") ||
-			fileRepoRelative == homeworkInstructionsFile {
+		if ignored.Match(fileRepoRelative) {
 			log.Printf("Skipping internal/config file: %s", fileRepoRelative)
 			continue
 		}
 
-		pathForReading := fileRepoRelative
-		if !strings.HasPrefix(fileRepoRelative, "correctness-tester/") {
-			pathForReading = filepath.Join(repoRootPrefix, fileRepoRelative)
+		// ignored already excludes the graders' own source directories (see
+		// builtinDefaults in internal/fileset), so every path reaching here
+		// lives elsewhere in the repo and needs repoRootPrefix joined in.
+		pathForReading := filepath.Join(repoRootPrefix, fileRepoRelative)
+
+		assignment, _ := gradingConfig.AssignmentFor(fileRepoRelative)
+		key := assignment.PathPrefix
+		g, ok := groups[key]
+		if !ok {
+			g = &assignmentGroup{assignment: assignment}
+			groups[key] = g
+			groupOrder = append(groupOrder, key)
 		}
-
-		filteredFiles = append(filteredFiles, pathForReading)
+		g.displayPaths = append(g.displayPaths, fileRepoRelative)
+		g.adjustedPaths = append(g.adjustedPaths, pathForReading)
 	}
 
-	if len(filteredFiles) == 0 {
+	if len(groups) == 0 {
 		fmt.Println("No relevant code files found for evaluation after filtering. Skipping evaluation.")
+		act.EndGroup()
 		os.Exit(0)
 	}
+	act.EndGroup()
 
-	// 2. Read Homework Instructions
-	// Homework instructions file is at the repo root, so its path needs to be adjusted relative to the script's CWD
-	actualHomeworkInstructionsFile := filepath.Join(repoRootPrefix, homeworkInstructionsFile)
-	homeworkInstructions, err := ioutil.ReadFile(actualHomeworkInstructionsFile)
+	clientGenAI, err := genai.NewClient(ctx, option.WithAPIKey(apiKey)) // Renamed client to avoid http.Client name conflict
 	if err != nil {
-		log.Fatalf("Error reading homework instructions file '%s': %v", actualHomeworkInstructionsFile, err)
+		log.Fatalf("Error creating Gemini client: %v", err)
 	}
-
-	// 3. Read Changed Code Files
-	var allCodeContent strings.Builder
-	for _, fileAdjustedPath := range filteredFiles {
-		code, err := ioutil.ReadFile(fileAdjustedPath)
+	defer clientGenAI.Close()
+	model := clientGenAI.GenerativeModel("gemini-2.0-flash")
+
+	// Each assignment opens its own "prompt" group around its Gemini call
+	// (see gradeAssignment); workflow commands don't support nesting, so this
+	// loop itself stays outside any group.
+	verdict := GeminiVerdict{Approved: true}
+	for _, key := range groupOrder {
+		g := *groups[key]
+		label := key
+		if label == "" {
+			label = "(default)"
+		}
+		fmt.Printf("Evaluating assignment %q (%d file(s))...\n", label, len(g.displayPaths))
+		assignmentVerdict, err := gradeAssignment(ctx, act, model, repoRootPrefix, g)
 		if err != nil {
-			log.Printf("Warning: Could not read file '%s': %v", fileAdjustedPath, err)
-			continue
+			log.Fatalf("Error evaluating assignment %q: %v", label, err)
 		}
-		// The header should use the original repo-relative path for Gemini's context
-		// We need to strip the '..' prefix if it was added for display.
-		displayFileName := strings.TrimPrefix(fileAdjustedPath, repoRootPrefix)
-		allCodeContent.WriteString(fmt.Sprintf("--- File: %s ---\n", displayFileName))
-		allCodeContent.Write(code)
-		allCodeContent.WriteString("\n\n")
+		verdict.Approved = verdict.Approved && assignmentVerdict.Approved
+		verdict.Findings = append(verdict.Findings, assignmentVerdict.Findings...)
+		if verdict.Summary != "" {
+			verdict.Summary += "\n"
+		}
+		verdict.Summary += fmt.Sprintf("[%s] %s", label, assignmentVerdict.Summary)
 	}
 
-	if allCodeContent.Len() == 0 {
-		fmt.Println("No code could be read from the changed files. Skipping evaluation.")
-		os.Exit(0)
+	fmt.Println("--- Gemini Feedback ---")
+	fmt.Println(verdict.Summary)
+	fmt.Println("-----------------------")
+
+	act.Group("respond")
+	for _, finding := range verdict.Findings {
+		act.Annotate(actions.Annotation{
+			Severity: finding.severity(),
+			File:     finding.File,
+			Line:     finding.Line,
+			Message:  finding.Message,
+		})
 	}
 
-	// 4. Construct Prompt
-	prompt := fmt.Sprintf(geminiPromptTemplate, string(homeworkInstructions), allCodeContent.String())
-
-	// 5. Call Gemini API
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		log.Fatalf("GEMINI_API_KEY environment variable not set. Please add it as a GitHub Secret.")
-	}
-
-	ctx := context.Background()
-	clientGenAI, err := genai.NewClient(ctx, option.WithAPIKey(apiKey)) // Renamed client to avoid http.Client name conflict
-	if err != nil {
-		log.Fatalf("Error creating Gemini client: %v", err)
-	}
-	defer clientGenAI.Close()
+	act.SetOutput("approved", fmt.Sprintf("%t", verdict.Approved))
+	act.SetOutput("rejected_count", fmt.Sprintf("%d", len(verdict.Findings)))
 
-	model := clientGenAI.GenerativeModel("gemini-2.0-flash") 
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	prNumber, err := resolvePullRequest(httpClient, repoOwner, repoName, headCommitSHA, githubToken)
 	if err != nil {
-		log.Fatalf("Error generating content from Gemini: %v", err)
+		log.Printf("Warning: Could not resolve pull request for commit %s: %v", headCommitSHA, err)
 	}
 
-	// 6. Process and Output Gemini Feedback
-	if resp != nil && len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-		var geminiOutput strings.Builder
-		for _, part := range resp.Candidates[0].Content.Parts {
-			if txt, ok := part.(genai.Text); ok {
-				geminiOutput.WriteString(string(txt))
-			}
+	if prNumber != 0 {
+		prFiles, err := fetchPullRequestFiles(httpClient, repoOwner, repoName, prNumber, githubToken)
+		if err != nil {
+			log.Printf("Warning: Could not fetch pull request #%d files, posting review without inline comments: %v", prNumber, err)
 		}
-		feedback := geminiOutput.String()
-
-		fmt.Println("--- Gemini Feedback ---")
-		fmt.Println(feedback)
-		fmt.Println("-----------------------")
-
-		// Output for GitHub Actions: Set as a step summary
-		summaryFilePath := os.Getenv("GITHUB_STEP_SUMMARY")
-		if summaryFilePath != "" {
-			err := ioutil.WriteFile(summaryFilePath, []byte(feedback), 0644)
-			if err != nil {
-				log.Printf("Warning: Failed to write to GITHUB_STEP_SUMMARY: %v", err)
-			}
+		skipped, err := postPullRequestReview(httpClient, repoOwner, repoName, prNumber, githubToken, headCommitSHA, verdict, prFiles)
+		if err != nil {
+			log.Printf("Warning: Failed to post pull request review: %v. Falling back to the step summary so feedback isn't lost.", err)
+			writeStepSummary(ctx, verdict.Summary)
 		} else {
-			log.Println("GITHUB_STEP_SUMMARY not found. Outputting feedback to stdout.")
+			fmt.Printf("Posted review to pull request #%d.\n", prNumber)
+			if skipped > 0 {
+				log.Printf("%d finding(s) referenced lines outside the diff and were omitted from inline comments; see the check run annotations instead.", skipped)
+			}
 		}
+	} else {
+		// Not associated with an open pull request: fall back to the step summary.
+		writeStepSummary(ctx, verdict.Summary)
+	}
 
-		// Optionally, if the feedback is "REJECTED", make the GitHub Action fail.
-		if strings.Contains(strings.ToUpper(feedback), "REJECTED") {
-			fmt.Println("::error::Code was rejected by Gemini. Check the step summary for details.")
-			os.Exit(1) // Fail the GitHub Action
-		}
+	statusState := checks.StateSuccess
+	conclusion := checks.ConclusionSuccess
+	if !verdict.Approved {
+		statusState = checks.StateFailure
+		conclusion = checks.ConclusionFailure
+	}
+	targetURL := checks.TargetURL()
+
+	if err := checks.PublishStatus(httpClient, repoOwner, repoName, headCommitSHA, githubToken, checks.StatusRequest{
+		Context:     "grader/correctness",
+		State:       statusState,
+		Description: truncateDescription(verdict.Summary),
+		TargetURL:   targetURL,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish commit status: %v", err)
+	}
 
-	} else {
-		fmt.Println("Gemini did not return any content.")
-		fmt.Println("::error::Gemini did not return any content for the evaluation.")
+	checkAnnotations := make([]checks.Annotation, 0, len(verdict.Findings))
+	for _, finding := range verdict.Findings {
+		checkAnnotations = append(checkAnnotations, checks.Annotation{
+			Path:            finding.File,
+			StartLine:       finding.Line,
+			EndLine:         finding.Line,
+			AnnotationLevel: finding.annotationLevel(),
+			Title:           "Correctness grader",
+			Message:         finding.Message,
+		})
+	}
+	if err := checks.PublishCheckRun(httpClient, repoOwner, repoName, githubToken, checks.CheckRunRequest{
+		Name:        "grader/correctness",
+		HeadSHA:     headCommitSHA,
+		Conclusion:  conclusion,
+		Summary:     verdict.Summary,
+		Annotations: checkAnnotations,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish check run: %v", err)
+	}
+	act.EndGroup()
+
+	if !verdict.Approved {
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
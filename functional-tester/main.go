@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+
+	"github.com/EliasMaDeRe/UCPTest/internal/actions"
+	"github.com/EliasMaDeRe/UCPTest/internal/checks"
+	"github.com/EliasMaDeRe/UCPTest/internal/config"
+	"github.com/EliasMaDeRe/UCPTest/internal/fileset"
+	"github.com/EliasMaDeRe/UCPTest/internal/retries"
+	"github.com/EliasMaDeRe/UCPTest/internal/sandbox"
+)
+
+// --- Structs and Constants ---
+const (
+	homeworkInstructionsFile = "homework0e3.txt"
+	compiledExecutableName   = "student_executable"
+)
+type TestCase struct {
+	Description    string `json:"description"`
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+}
+type TestCasesResponse struct{ TestCases []TestCase `json:"test_cases"` }
+type LanguageConfig struct {
+	Language string; GlobPattern string; CompileCmd []string; ExecuteCmd []string; DockerImage string
+}
+type Project struct {
+	LanguageConfig; EntryPointFile string; EntryPointBaseName string; EntryPointClassName string
+}
+type GitHubPushEvent struct {
+	HeadCommit struct { ID string `json:"id"` } `json:"head_commit"`
+	Repository struct { Name  string `json:"name"`; Owner struct { Login string `json:"login"` } `json:"owner"` } `json:"repository"`
+}
+type GitHubCommitDetails struct {
+	Files []struct { Filename string `json:"filename"`; Status string `json:"status"` } `json:"files"`
+}
+
+// --- Language and Prompt Configurations ---
+var supportedLanguages = map[string]LanguageConfig{
+	"Python": { Language: "Python", GlobPattern: "*.py", ExecuteCmd: []string{"python3", "__FILE__"} },
+	"Java":   { Language: "Java", GlobPattern: "*.java", CompileCmd: []string{"javac", "__FILE__"}, ExecuteCmd: []string{"java", "-cp", "..", "__CLASSNAME__"} },
+	"C++":    { Language: "C++", GlobPattern: "*.cpp", CompileCmd: []string{"g++", "__FILE__", "-o", compiledExecutableName, "-std=c++17"}, ExecuteCmd: []string{"./" + compiledExecutableName} },
+}
+const entryPointPromptTemplate = `You are a code analysis expert. Given the following list of filenames from a student's project, identify the single most likely main entry-point file. Respond with ONLY the filename and nothing else. FILENAMES: %s`
+const testGenPromptTemplate = `You are an expert Test Case Generator AI. Based on the provided homework instructions, create 5 diverse and effective test cases. Your response MUST be a single, valid JSON object.
+---
+Homework Instructions:
+%s
+---
+`
+// --- Helper functions ---
+func askAiForEntryPoint(ctx context.Context, client *genai.GenerativeModel, files []string) (string, error) {
+	fmt.Printf("Multiple potential entry points found: %v. Asking AI for the main file...\n", files)
+	var fileBasenames []string
+	for _, f := range files { fileBasenames = append(fileBasenames, filepath.Base(f)) }
+	prompt := fmt.Sprintf(entryPointPromptTemplate, strings.Join(fileBasenames, "\n"))
+	var resp *genai.GenerateContentResponse
+	err := retries.Wait(ctx, func() error {
+		var genErr error
+		resp, genErr = client.GenerateContent(ctx, genai.Text(prompt))
+		return genErr
+	}, retries.DefaultPolicy())
+	if err != nil { return "", fmt.Errorf("gemini failed to determine entry point: %w", err) }
+	if resp == nil || len(resp.Candidates) == 0 { return "", errors.New("gemini returned an empty response for entry point") }
+	aiChoice := strings.TrimSpace(string(resp.Candidates[0].Content.Parts[0].(genai.Text)))
+	for _, basename := range fileBasenames { if basename == aiChoice { fmt.Printf("AI selected '%s' as the entry point.\n", aiChoice); return aiChoice, nil } }
+	return "", fmt.Errorf("AI chose '%s', which is not in the list of found files: %v", aiChoice, fileBasenames)
+}
+
+func buildCommand(args []string, project *Project) []string {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.Replace(arg, "__FILE__", project.EntryPointFile, -1)
+		arg = strings.Replace(arg, "__CLASSNAME__", project.EntryPointClassName, -1)
+		result[i] = arg
+	}
+	return result
+}
+
+// findProjectFiles walks baseDir recursively for files matching globPattern
+// (a plain "*.ext" shell pattern, matched against the basename only, so it
+// finds files nested under an assignment's path_prefix), skipping anything
+// ignored excludes.
+func findProjectFiles(repoRoot, baseDir, globPattern string, ignored *fileset.FileSet) ([]string, error) {
+	var matches []string
+	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.IsDir() {
+			return nil
+		}
+		matched, matchErr := filepath.Match(globPattern, info.Name())
+		if matchErr != nil {
+			return matchErr
+		}
+		if !matched {
+			return nil
+		}
+		if rel, relErr := filepath.Rel(repoRoot, path); relErr == nil && ignored.Match(rel) {
+			return nil
+		}
+		matches = append(matches, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// assignmentGroup collects the changed files routed to one Assignment (or
+// the implicit single-assignment default when no grader.yaml is present),
+// mirroring the correctness-tester's grouping.
+type assignmentGroup struct {
+	assignment   config.Assignment
+	displayPaths []string
+}
+
+// runAssignment executes the full generate-compile-run pipeline for one
+// assignment group and returns a human-readable summary alongside the
+// pass/fail counts.
+func runAssignment(ctx context.Context, act *actions.Writer, model *genai.GenerativeModel, repoRoot string, g assignmentGroup, ignored *fileset.FileSet, attrs *fileset.AttributeSet) (summary string, total int, failed int, err error) {
+	label := g.assignment.PathPrefix
+	if label == "" {
+		label = "(default)"
+	}
+
+	var detectedLangConfig LanguageConfig
+	for _, changedFile := range g.displayPaths {
+		ext := strings.TrimPrefix(filepath.Ext(changedFile), ".")
+		for _, langCfg := range supportedLanguages {
+			if ext == strings.TrimPrefix(langCfg.GlobPattern, "*.") {
+				detectedLangConfig = langCfg
+			}
+		}
+	}
+	if detectedLangConfig.Language == "" {
+		return "", 0, 0, fmt.Errorf("no recognized language files among changed files for assignment %q", label)
+	}
+	fmt.Printf("[%s] Detected changes to %s files: %v\n", label, detectedLangConfig.Language, g.displayPaths)
+
+	if override, ok := g.assignment.LanguageOverrides[strings.TrimPrefix(filepath.Ext(g.displayPaths[0]), ".")]; ok {
+		if override.GlobPattern != "" {
+			detectedLangConfig.GlobPattern = override.GlobPattern
+		}
+		if override.CompileCmd != nil {
+			detectedLangConfig.CompileCmd = override.CompileCmd
+		}
+		if override.ExecuteCmd != nil {
+			detectedLangConfig.ExecuteCmd = override.ExecuteCmd
+		}
+		if override.DockerImage != "" {
+			detectedLangConfig.DockerImage = override.DockerImage
+		}
+	}
+
+	timeoutSeconds := g.assignment.Timeout()
+	instructionsFile := g.assignment.InstructionsFile
+	if instructionsFile == "" {
+		instructionsFile = homeworkInstructionsFile
+	}
+
+	baseDir := repoRoot
+	if g.assignment.PathPrefix != "" {
+		baseDir = filepath.Join(repoRoot, g.assignment.PathPrefix)
+	}
+	allProjectFiles, err := findProjectFiles(repoRoot, baseDir, detectedLangConfig.GlobPattern, ignored)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("searching for %s files under %s: %w", detectedLangConfig.GlobPattern, baseDir, err)
+	}
+	if len(allProjectFiles) == 0 {
+		return "", 0, 0, fmt.Errorf("detected %s change, but could not find any %s files under %s", detectedLangConfig.Language, detectedLangConfig.GlobPattern, baseDir)
+	}
+
+	// selectedRelPath is relative to baseDir and may include a subdirectory,
+	// since findProjectFiles now walks baseDir recursively.
+	var selectedRelPath string
+	if explicit, ok := attrs.For(g.displayPaths[0]); ok && explicit.Entry != "" {
+		selectedRelPath = explicit.Entry
+		fmt.Printf("[%s] .graderattributes pins the entry point to '%s'.\n", label, selectedRelPath)
+	} else if len(allProjectFiles) == 1 {
+		rel, relErr := filepath.Rel(baseDir, allProjectFiles[0])
+		if relErr != nil {
+			rel = filepath.Base(allProjectFiles[0])
+		}
+		selectedRelPath = rel
+		fmt.Printf("[%s] Found single %s file: using '%s' as the entry point.\n", label, detectedLangConfig.Language, selectedRelPath)
+	} else {
+		aiChoice, err := askAiForEntryPoint(ctx, model, allProjectFiles)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		selectedRelPath = aiChoice
+		for _, f := range allProjectFiles {
+			if filepath.Base(f) != aiChoice {
+				continue
+			}
+			if rel, relErr := filepath.Rel(baseDir, f); relErr == nil {
+				selectedRelPath = rel
+			}
+			break
+		}
+	}
+
+	entryBaseName := filepath.Base(selectedRelPath)
+	project := &Project{
+		LanguageConfig:      detectedLangConfig,
+		EntryPointFile:      filepath.Join(baseDir, selectedRelPath),
+		EntryPointBaseName:  entryBaseName,
+		EntryPointClassName: strings.TrimSuffix(entryBaseName, filepath.Ext(entryBaseName)),
+	}
+
+	fmt.Printf("[%s] Generating test cases...\n", label)
+	actualHomeworkInstructionsFile := filepath.Join(repoRoot, instructionsFile)
+	homeworkInstructions, _ := ioutil.ReadFile(actualHomeworkInstructionsFile)
+	prompt := fmt.Sprintf(testGenPromptTemplate, string(homeworkInstructions))
+	act.Group("prompt")
+	var resp *genai.GenerateContentResponse
+	genErr := retries.Wait(ctx, func() error {
+		var err error
+		resp, err = model.GenerateContent(ctx, genai.Text(prompt))
+		return err
+	}, retries.DefaultPolicy())
+	act.EndGroup()
+	if genErr != nil {
+		return "", 0, 0, fmt.Errorf("generating test cases from Gemini: %w", genErr)
+	}
+	jsonPart := resp.Candidates[0].Content.Parts[0].(genai.Text)
+	jsonStr := strings.Trim(string(jsonPart), " \n\t`json")
+	var testCasesResponse TestCasesResponse
+	_ = json.Unmarshal([]byte(jsonStr), &testCasesResponse)
+	fmt.Printf("[%s] Successfully generated %d test cases.\n", label, len(testCasesResponse.TestCases))
+
+	if project.CompileCmd != nil {
+		cmdArgs := buildCommand(project.CompileCmd, project)
+		fmt.Printf("[%s] Compiling student code: %s\n", label, strings.Join(cmdArgs, " "))
+		cmdBuild := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+		buildOutput, err := cmdBuild.CombinedOutput()
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("failed to compile student code: %w\nCompiler Output:\n%s", err, string(buildOutput))
+		}
+		fmt.Printf("[%s] Compilation successful.\n", label)
+	}
+
+	execArgs := buildCommand(project.ExecuteCmd, project)
+	limits := sandbox.DefaultLimits(time.Duration(timeoutSeconds) * time.Second)
+	limits.DockerImage = project.DockerImage
+	for i, tc := range testCasesResponse.TestCases {
+		fmt.Printf("[%s] --- Running Test Case %d: %s ---\n", label, i+1, tc.Description)
+		result, runErr := sandbox.Run(ctx, limits, tc.Input, execArgs[0], execArgs[1:])
+		if runErr != nil {
+			return "", 0, 0, fmt.Errorf("failed to run student code: %w", runErr)
+		}
+		actualOutput := strings.TrimSpace(result.Stdout)
+		expectedOutput := strings.TrimSpace(tc.ExpectedOutput)
+		fmt.Printf("Input: '%s'\nExpected Output: '%s'\nActual Output:   '%s'\n", tc.Input, expectedOutput, actualOutput)
+		switch {
+		case result.TimedOut:
+			fmt.Println("Result: TIMEOUT")
+			failed++
+		case result.OOMKilled:
+			fmt.Println("Result: OOM")
+			failed++
+		case result.Truncated:
+			fmt.Println("Result: TRUNCATED")
+			failed++
+		case result.Err == nil && actualOutput == expectedOutput:
+			fmt.Println("Result: PASSED")
+		default:
+			fmt.Println("Result: FAILED")
+			failed++
+		}
+	}
+
+	total = len(testCasesResponse.TestCases)
+	summary = fmt.Sprintf("[%s] Passed %d out of %d test cases for the %s project.", label, total-failed, total, project.Language)
+	return summary, total, failed, nil
+}
+
+// --- Main application logic ---
+func main() {
+	act := actions.Default
+
+	// Mask secrets before anything else is written to stdout.
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	act.AddMask(githubToken)
+	act.AddMask(apiKey)
+	if githubToken == "" { log.Fatalf("GITHUB_TOKEN environment variable not set.") }
+	if apiKey == "" { log.Fatalf("GEMINI_API_KEY environment variable not set.") }
+
+	act.Group("fetch")
+	// 1. READ PUSH EVENT TO GET CHANGED FILES
+	fmt.Println("Reading GitHub push event...")
+	githubEventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if githubEventPath == "" { log.Fatalf("GITHUB_EVENT_PATH environment variable not set.") }
+	eventPayloadBytes, err := ioutil.ReadFile(githubEventPath)
+	if err != nil { log.Fatalf("Failed to read GITHUB_EVENT_PATH: %v", err) }
+	var pushEvent GitHubPushEvent
+	if err := json.Unmarshal(eventPayloadBytes, &pushEvent); err != nil { log.Fatalf("Failed to unmarshal GitHub push event payload: %v", err) }
+
+	headCommitSHA := pushEvent.HeadCommit.ID
+	repoOwner := pushEvent.Repository.Owner.Login
+	repoName := pushEvent.Repository.Name
+	if headCommitSHA == "" || repoOwner == "" || repoName == "" { log.Fatalf("Could not extract commit SHA, repo owner, or repo name from event payload.") }
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", repoOwner, repoName, headCommitSHA), nil)
+	req.Header.Set("Authorization", "token "+githubToken)
+	httpClient := &http.Client{}
+
+	var apiResponseBody []byte
+	fetchErr := retries.Wait(context.Background(), func() error {
+		respAPI, doErr := httpClient.Do(req)
+		if doErr != nil { return doErr }
+		defer respAPI.Body.Close()
+		body, readErr := ioutil.ReadAll(respAPI.Body)
+		if readErr != nil { return readErr }
+		if respAPI.StatusCode != http.StatusOK {
+			return retries.NewHTTPError(respAPI, fmt.Errorf("GitHub API request failed with status %d: %s", respAPI.StatusCode, string(body)))
+		}
+		apiResponseBody = body
+		return nil
+	}, retries.DefaultPolicy())
+	if fetchErr != nil { log.Fatalf("Error fetching commit details: %v", fetchErr) }
+
+	var commitDetails GitHubCommitDetails
+	if err := json.Unmarshal(apiResponseBody, &commitDetails); err != nil { log.Fatalf("Error unmarshaling GitHub API response: %v", err) }
+	act.EndGroup()
+
+	act.Group("filter")
+	// 2. ROUTE EACH CHANGED FILE TO ITS ASSIGNMENT, IF grader.yaml DECLARES ANY
+	gradingConfig, err := config.Load("..")
+	if err != nil {
+		log.Fatalf("Error loading grader.yaml: %v", err)
+	}
+
+	groups := map[string]*assignmentGroup{}
+	var groupOrder []string
+	for _, changedFile := range commitDetails.Files {
+		ext := strings.TrimPrefix(filepath.Ext(changedFile.Filename), ".")
+		isSourceFile := false
+		for _, langCfg := range supportedLanguages {
+			if ext == strings.TrimPrefix(langCfg.GlobPattern, "*.") {
+				isSourceFile = true
+				break
+			}
+		}
+		if !isSourceFile {
+			continue
+		}
+
+		assignment, _ := gradingConfig.AssignmentFor(changedFile.Filename)
+		key := assignment.PathPrefix
+		g, ok := groups[key]
+		if !ok {
+			g = &assignmentGroup{assignment: assignment}
+			groups[key] = g
+			groupOrder = append(groupOrder, key)
+		}
+		g.displayPaths = append(g.displayPaths, changedFile.Filename)
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No relevant code files (.py, .java, .cpp) changed in this push. Skipping functional tests.")
+		act.EndGroup()
+		os.Exit(0)
+	}
+	act.EndGroup()
+
+	// 3. RUN THE GENERATE-COMPILE-RUN PIPELINE FOR EACH ASSIGNMENT
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey));
+	if err != nil { log.Fatalf("Error creating Gemini client: %v", err) }
+	defer client.Close()
+	model := client.GenerativeModel("gemini-1.5-flash")
+
+	repoRoot := ".."
+	ignored, err := fileset.Load(repoRoot, []string{homeworkInstructionsFile, "grader.yaml", ".grader.yml"})
+	if err != nil { log.Fatalf("Error loading .graderignore: %v", err) }
+	attrs, err := fileset.LoadAttributes(repoRoot)
+	if err != nil { log.Fatalf("Error loading .graderattributes: %v", err) }
+
+	// Each assignment opens its own "prompt" group around its test-generation
+	// call (see runAssignment); workflow commands don't support nesting, so
+	// this loop itself stays outside any group.
+	var totalTests, failedTests int
+	var summaries []string
+	for _, key := range groupOrder {
+		g := *groups[key]
+		label := key
+		if label == "" {
+			label = "(default)"
+		}
+		fmt.Printf("Running functional tests for assignment %q (%d file(s))...\n", label, len(g.displayPaths))
+		summary, total, failed, err := runAssignment(ctx, act, model, repoRoot, g, ignored, attrs)
+		if err != nil {
+			log.Fatalf("Error running functional tests for assignment %q: %v", label, err)
+		}
+		totalTests += total
+		failedTests += failed
+		summaries = append(summaries, summary)
+	}
+
+	fmt.Println("\n--- Functional Test Summary ---")
+	summary := strings.Join(summaries, "\n")
+	fmt.Println(summary)
+
+	act.Group("respond")
+	statusState := checks.StateSuccess
+	conclusion := checks.ConclusionSuccess
+	if failedTests > 0 {
+		statusState = checks.StateFailure
+		conclusion = checks.ConclusionFailure
+	}
+	if err := checks.PublishStatus(httpClient, repoOwner, repoName, headCommitSHA, githubToken, checks.StatusRequest{
+		Context:     "grader/functional",
+		State:       statusState,
+		Description: fmt.Sprintf("Passed %d out of %d test cases.", totalTests-failedTests, totalTests),
+		TargetURL:   checks.TargetURL(),
+	}); err != nil {
+		log.Printf("Warning: Failed to publish commit status: %v", err)
+	}
+	if err := checks.PublishCheckRun(httpClient, repoOwner, repoName, githubToken, checks.CheckRunRequest{
+		Name:       "grader/functional",
+		HeadSHA:    headCommitSHA,
+		Conclusion: conclusion,
+		Summary:    summary,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish check run: %v", err)
+	}
+
+	act.EndGroup()
+
+	if failedTests > 0 {
+		act.Error(actions.Annotation{Message: summary})
+		os.Exit(1)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+// Package actions implements the subset of the GitHub Actions workflow-command
+// protocol (https://docs.github.com/actions/using-workflow-commands-for-github-actions)
+// that the graders rely on: log grouping, file/line-scoped annotations, secret
+// masking, and step outputs. It exists so the two grader binaries stop hand
+// rolling "::error::" fmt.Printf calls and instead go through one place that
+// knows how to escape workflow-command data and properties correctly.
+package actions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Writer emits GitHub Actions workflow commands to an underlying io.Writer,
+// normally os.Stdout.
+type Writer struct {
+	out io.Writer
+}
+
+// New returns a Writer that writes workflow commands to out.
+func New(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Default is the Writer graders should use unless they need to capture
+// output for testing.
+var Default = New(os.Stdout)
+
+// Severity is the level of an annotation emitted via Notice, Warning or Error.
+type Severity string
+
+const (
+	SeverityNotice  Severity = "notice"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Annotation is a single file/line-scoped finding rendered as an inline
+// squiggle in the GitHub "Files changed" view.
+type Annotation struct {
+	Severity Severity
+	File     string
+	Line     int
+	EndLine  int
+	Col      int
+	EndCol   int
+	Title    string
+	Message  string
+}
+
+// Group opens a collapsible log group in the Actions UI. Callers must pair it
+// with a matching EndGroup.
+func (w *Writer) Group(name string) {
+	fmt.Fprintf(w.out, "::group::%s\n", escapeData(name))
+}
+
+// EndGroup closes the most recently opened group.
+func (w *Writer) EndGroup() {
+	fmt.Fprintln(w.out, "::endgroup::")
+}
+
+// AddMask tells the Actions runner to replace value with "***" in all future
+// log output. It is a no-op for an empty value so callers can pass optional
+// secrets without an extra guard.
+func (w *Writer) AddMask(value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w.out, "::add-mask::%s\n", escapeData(value))
+}
+
+// SetOutput exposes name=value as a step output consumable by
+// `${{ steps.x.outputs.name }}` in downstream jobs. It appends a delimited
+// entry to the file named by the GITHUB_OUTPUT environment variable; the
+// `::set-output::` workflow command this replaces was disabled on
+// GitHub-hosted runners in mid-2023 and is now a silent no-op.
+func (w *Writer) SetOutput(name, value string) {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		fmt.Fprintf(w.out, "::warning::GITHUB_OUTPUT is not set; cannot set output %q\n", name)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(w.out, "::warning::failed to open GITHUB_OUTPUT: %v\n", err)
+		return
+	}
+	defer f.Close()
+	delimiter := "ghadelimiter_" + name
+	fmt.Fprintf(f, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+}
+
+// Notice emits a notice-level annotation.
+func (w *Writer) Notice(a Annotation) {
+	a.Severity = SeverityNotice
+	w.Annotate(a)
+}
+
+// Warning emits a warning-level annotation.
+func (w *Writer) Warning(a Annotation) {
+	a.Severity = SeverityWarning
+	w.Annotate(a)
+}
+
+// Error emits an error-level annotation.
+func (w *Writer) Error(a Annotation) {
+	a.Severity = SeverityError
+	w.Annotate(a)
+}
+
+// Annotate emits a workflow-command annotation at a.Severity, with whichever
+// of file/line/col properties are set.
+func (w *Writer) Annotate(a Annotation) {
+	if a.Severity == "" {
+		a.Severity = SeverityError
+	}
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeProperty(a.File))
+	}
+	if a.Line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", a.Line))
+	}
+	if a.EndLine > 0 {
+		props = append(props, fmt.Sprintf("endLine=%d", a.EndLine))
+	}
+	if a.Col > 0 {
+		props = append(props, fmt.Sprintf("col=%d", a.Col))
+	}
+	if a.EndCol > 0 {
+		props = append(props, fmt.Sprintf("endColumn=%d", a.EndCol))
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeProperty(a.Title))
+	}
+	fmt.Fprintf(w.out, "::%s %s::%s\n", a.Severity, strings.Join(props, ","), escapeData(a.Message))
+}
+
+// escapeData escapes the ":: ... ::" payload portion of a workflow command,
+// per the encoding GitHub Actions' toolkit uses for command values.
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return s
+}
+
+// escapeProperty escapes a "key=value" property within a workflow command,
+// which additionally requires escaping ":" and "," since they delimit
+// properties.
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	s = strings.ReplaceAll(s, ",", "%2C")
+	return s
+}
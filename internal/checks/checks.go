@@ -0,0 +1,253 @@
+// Package checks publishes grader results to GitHub's two check surfaces:
+// the older commit Status API (POST /repos/{owner}/{repo}/statuses/{sha})
+// and the newer Checks API (POST /repos/{owner}/{repo}/check-runs), so
+// students see a check badge on their commit and pull request without
+// opening the Actions log.
+package checks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// State is a commit status state, per the Statuses API.
+type State string
+
+const (
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+	StateError   State = "error"
+)
+
+// maxAnnotationsPerRequest is the limit the Checks API enforces per call;
+// larger annotation sets must be sent as follow-up PATCH requests.
+const maxAnnotationsPerRequest = 50
+
+// TargetURL builds the Actions run URL GitHub shows next to a status or
+// check, from the standard GITHUB_SERVER_URL/GITHUB_REPOSITORY/GITHUB_RUN_ID
+// environment variables set by the runner.
+func TargetURL() string {
+	server := os.Getenv("GITHUB_SERVER_URL")
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	runID := os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, runID)
+}
+
+// StatusRequest is a single commit status update.
+type StatusRequest struct {
+	Context     string
+	State       State
+	Description string
+	TargetURL   string
+}
+
+// PublishStatus posts a commit status for sha via the legacy Statuses API.
+func PublishStatus(client *http.Client, owner, repo, sha, token string, req StatusRequest) error {
+	body, err := json.Marshal(struct {
+		State       string `json:"state"`
+		TargetURL   string `json:"target_url,omitempty"`
+		Description string `json:"description,omitempty"`
+		Context     string `json:"context"`
+	}{
+		State:       string(req.State),
+		TargetURL:   req.TargetURL,
+		Description: req.Description,
+		Context:     req.Context,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest("POST", fmt.Sprintf("https://api.github.com/repos/%s/%s/statuses/%s", owner, repo, sha), strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/vnd.github.v3+json")
+	httpReq.Header.Set("Authorization", "token "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("POST commit status failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// AnnotationLevel is the severity of a Checks API annotation.
+type AnnotationLevel string
+
+const (
+	AnnotationNotice  AnnotationLevel = "notice"
+	AnnotationWarning AnnotationLevel = "warning"
+	AnnotationFailure AnnotationLevel = "failure"
+)
+
+// Annotation is a single file/line finding rendered on a check run.
+type Annotation struct {
+	Path            string
+	StartLine       int
+	EndLine         int
+	AnnotationLevel AnnotationLevel
+	Title           string
+	Message         string
+}
+
+func (a Annotation) marshal() map[string]interface{} {
+	return map[string]interface{}{
+		"path":             a.Path,
+		"start_line":       a.StartLine,
+		"end_line":         a.EndLine,
+		"annotation_level": string(a.AnnotationLevel),
+		"title":            a.Title,
+		"message":          a.Message,
+	}
+}
+
+// Conclusion is the final verdict of a completed check run.
+type Conclusion string
+
+const (
+	ConclusionSuccess Conclusion = "success"
+	ConclusionFailure Conclusion = "failure"
+	ConclusionNeutral Conclusion = "neutral"
+)
+
+// CheckRunRequest describes a completed check run to publish.
+type CheckRunRequest struct {
+	Name        string
+	HeadSHA     string
+	Conclusion  Conclusion
+	Summary     string
+	Annotations []Annotation
+}
+
+// PublishCheckRun creates a completed check run, batching annotations into
+// groups of maxAnnotationsPerRequest via follow-up PATCH calls since the
+// Checks API rejects more than 50 per request.
+func PublishCheckRun(client *http.Client, owner, repo, token string, req CheckRunRequest) error {
+	first := req.Annotations
+	rest := []Annotation(nil)
+	if len(first) > maxAnnotationsPerRequest {
+		rest = first[maxAnnotationsPerRequest:]
+		first = first[:maxAnnotationsPerRequest]
+	}
+
+	checkRunID, err := createCheckRun(client, owner, repo, token, req, first)
+	if err != nil {
+		return err
+	}
+
+	for len(rest) > 0 {
+		batch := rest
+		if len(batch) > maxAnnotationsPerRequest {
+			batch = batch[:maxAnnotationsPerRequest]
+		}
+		rest = rest[len(batch):]
+		if err := appendAnnotations(client, owner, repo, token, checkRunID, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createCheckRun(client *http.Client, owner, repo, token string, req CheckRunRequest, annotations []Annotation) (int64, error) {
+	body, err := json.Marshal(checkRunPayload(req, annotations))
+	if err != nil {
+		return 0, err
+	}
+
+	httpReq, err := http.NewRequest("POST", fmt.Sprintf("https://api.github.com/repos/%s/%s/check-runs", owner, repo), strings.NewReader(string(body)))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Accept", "application/vnd.github.v3+json")
+	httpReq.Header.Set("Authorization", "token "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return 0, fmt.Errorf("POST check-runs failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+func appendAnnotations(client *http.Client, owner, repo, token string, checkRunID int64, annotations []Annotation) error {
+	annotationPayloads := make([]map[string]interface{}, len(annotations))
+	for i, a := range annotations {
+		annotationPayloads[i] = a.marshal()
+	}
+	body, err := json.Marshal(struct {
+		Output struct {
+			Annotations []map[string]interface{} `json:"annotations"`
+		} `json:"output"`
+	}{
+		Output: struct {
+			Annotations []map[string]interface{} `json:"annotations"`
+		}{Annotations: annotationPayloads},
+	})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest("PATCH", fmt.Sprintf("https://api.github.com/repos/%s/%s/check-runs/%d", owner, repo, checkRunID), strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/vnd.github.v3+json")
+	httpReq.Header.Set("Authorization", "token "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("PATCH check-runs failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func checkRunPayload(req CheckRunRequest, annotations []Annotation) map[string]interface{} {
+	annotationPayloads := make([]map[string]interface{}, len(annotations))
+	for i, a := range annotations {
+		annotationPayloads[i] = a.marshal()
+	}
+	return map[string]interface{}{
+		"name":        req.Name,
+		"head_sha":    req.HeadSHA,
+		"status":      "completed",
+		"conclusion":  string(req.Conclusion),
+		"details_url": TargetURL(),
+		"output": map[string]interface{}{
+			"title":       req.Name,
+			"summary":     req.Summary,
+			"annotations": annotationPayloads,
+		},
+	}
+}
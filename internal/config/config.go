@@ -0,0 +1,102 @@
+// Package config loads grader.yaml (or .grader.yml), the multi-assignment
+// configuration that replaces the graders' old hard-coded single homework
+// file and flat repo layout assumption. A classroom repo declares one
+// Assignment per homework, and callers route each changed file to the
+// assignment whose path_prefix it falls under.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTimeoutSeconds is used for an Assignment that doesn't set
+// timeout_seconds.
+const DefaultTimeoutSeconds = 10
+
+// candidateFiles are tried, in order, relative to the repo root.
+var candidateFiles = []string{"grader.yaml", ".grader.yml"}
+
+// LanguageConfig overrides a built-in supportedLanguages entry for files
+// under a given Assignment. Fields left empty fall back to the built-in
+// default for that language.
+type LanguageConfig struct {
+	GlobPattern string   `yaml:"glob_pattern"`
+	CompileCmd  []string `yaml:"compile_cmd"`
+	ExecuteCmd  []string `yaml:"execute_cmd"`
+	DockerImage string   `yaml:"docker_image"`
+}
+
+// Assignment describes one homework within the repo.
+type Assignment struct {
+	PathPrefix        string                    `yaml:"path_prefix"`
+	InstructionsFile  string                    `yaml:"instructions_file"`
+	RubricFile        string                    `yaml:"rubric_file"`
+	LanguageOverrides map[string]LanguageConfig `yaml:"language_overrides"`
+	TimeoutSeconds    int                       `yaml:"timeout_seconds"`
+}
+
+// Timeout returns the assignment's configured timeout, or DefaultTimeoutSeconds.
+func (a Assignment) Timeout() int {
+	if a.TimeoutSeconds <= 0 {
+		return DefaultTimeoutSeconds
+	}
+	return a.TimeoutSeconds
+}
+
+// Config is the parsed contents of grader.yaml.
+type Config struct {
+	Assignments []Assignment `yaml:"assignments"`
+}
+
+// Load reads and parses grader.yaml (or .grader.yml) from repoRoot. It
+// returns a nil *Config and a nil error when neither file exists; calling
+// AssignmentFor on that nil Config yields the zero Assignment, so callers
+// that don't care about multi-assignment repos keep working.
+func Load(repoRoot string) (*Config, error) {
+	for _, name := range candidateFiles {
+		path := filepath.Join(repoRoot, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+		var cfg Config
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+		return &cfg, nil
+	}
+	return nil, nil
+}
+
+// AssignmentFor returns the Assignment whose path_prefix is the longest
+// match for repoRelativePath, and whether any assignment matched at all.
+func (c *Config) AssignmentFor(repoRelativePath string) (Assignment, bool) {
+	if c == nil {
+		return Assignment{}, false
+	}
+	cleanPath := filepath.ToSlash(repoRelativePath)
+
+	candidates := make([]Assignment, len(c.Assignments))
+	copy(candidates, c.Assignments)
+	sort.Slice(candidates, func(i, j int) bool {
+		return len(candidates[i].PathPrefix) > len(candidates[j].PathPrefix)
+	})
+
+	for _, a := range candidates {
+		prefix := strings.TrimSuffix(filepath.ToSlash(a.PathPrefix), "/")
+		if prefix == "" || cleanPath == prefix || strings.HasPrefix(cleanPath, prefix+"/") {
+			return a, true
+		}
+	}
+	return Assignment{}, false
+}
@@ -0,0 +1,80 @@
+// Package diffpos converts absolute line numbers in a file's "after" version
+// into the `position` values the (now legacy) GitHub pull-request
+// review-comments endpoint expects: an offset counted from the first line of
+// the unified-diff hunk header, not the file itself.
+package diffpos
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// Mapper resolves a line number in the new version of a file to a diff
+// position, for a single file's patch as returned by the GitHub "list pull
+// request files" API (the `patch` field).
+type Mapper struct {
+	// positionForLine maps a line number in the new file to its position
+	// within the patch.
+	positionForLine map[int]int
+}
+
+// NewMapper parses a unified-diff patch for one file and builds a Mapper.
+func NewMapper(patch string) (*Mapper, error) {
+	m := &Mapper{positionForLine: map[int]int{}}
+	if patch == "" {
+		return m, nil
+	}
+
+	lines := strings.Split(patch, "\n")
+	position := 0
+	newLine := 0
+	inHunk := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			match := hunkHeaderRe.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("diffpos: malformed hunk header: %q", line)
+			}
+			start, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("diffpos: malformed hunk header: %q: %w", line, err)
+			}
+			newLine = start
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		// A patch ending in "\n" splits into a trailing empty string, which is
+		// not a diff line (context lines carry a leading space).
+		if line == "" {
+			continue
+		}
+		position++
+		switch {
+		case strings.HasPrefix(line, "-"):
+			// Line only present in the old file; doesn't consume a new-line number.
+		case strings.HasPrefix(line, "+"):
+			m.positionForLine[newLine] = position
+			newLine++
+		default:
+			// Context line, present in both versions.
+			m.positionForLine[newLine] = position
+			newLine++
+		}
+	}
+	return m, nil
+}
+
+// Position returns the diff position for line in the new file, and whether
+// that line actually appears in the patch (unchanged lines far from any hunk
+// are not part of the patch and have no valid position).
+func (m *Mapper) Position(line int) (int, bool) {
+	pos, ok := m.positionForLine[line]
+	return pos, ok
+}
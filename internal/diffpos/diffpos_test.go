@@ -0,0 +1,43 @@
+package diffpos
+
+import "testing"
+
+func TestNewMapperPosition(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n line1\n+line2\n line3"
+	m, err := NewMapper(patch)
+	if err != nil {
+		t.Fatalf("NewMapper returned error: %v", err)
+	}
+
+	cases := []struct {
+		line    int
+		wantPos int
+		wantOK  bool
+	}{
+		{line: 1, wantPos: 1, wantOK: true},
+		{line: 2, wantPos: 2, wantOK: true},
+		{line: 3, wantPos: 3, wantOK: true},
+		{line: 99, wantPos: 0, wantOK: false},
+	}
+	for _, c := range cases {
+		pos, ok := m.Position(c.line)
+		if ok != c.wantOK || pos != c.wantPos {
+			t.Errorf("Position(%d) = (%d, %v), want (%d, %v)", c.line, pos, ok, c.wantPos, c.wantOK)
+		}
+	}
+}
+
+func TestNewMapperMultipleHunks(t *testing.T) {
+	patch := "@@ -1,2 +1,2 @@\n-old\n+new\n context\n@@ -10,2 +10,3 @@\n more\n+added\n"
+	m, err := NewMapper(patch)
+	if err != nil {
+		t.Fatalf("NewMapper returned error: %v", err)
+	}
+
+	if pos, ok := m.Position(1); !ok || pos != 2 {
+		t.Errorf("Position(1) = (%d, %v), want (2, true)", pos, ok)
+	}
+	if pos, ok := m.Position(11); !ok || pos != 5 {
+		t.Errorf("Position(11) = (%d, %v), want (5, true)", pos, ok)
+	}
+}
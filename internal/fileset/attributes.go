@@ -0,0 +1,97 @@
+package fileset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Attributes are the per-path overrides a .graderattributes rule can set,
+// in the spirit of git's own attribute files.
+type Attributes struct {
+	Language string
+	Entry    string
+	Timeout  time.Duration
+}
+
+// attributeRule is one parsed "<pattern> key=value ..." line.
+type attributeRule struct {
+	re   *regexp.Regexp
+	attr Attributes
+}
+
+// AttributeSet resolves the Attributes that apply to a given path, in
+// last-match-wins order (the same precedence .gitattributes uses).
+type AttributeSet struct {
+	rules []attributeRule
+}
+
+// LoadAttributes parses .graderattributes from repoRoot. A missing file
+// yields an empty, always-miss AttributeSet.
+func LoadAttributes(repoRoot string) (*AttributeSet, error) {
+	set := &AttributeSet{}
+	data, err := ioutil.ReadFile(filepath.Join(repoRoot, ".graderattributes"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return set, nil
+		}
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		glob := strings.TrimPrefix(fields[0], "/")
+
+		var attr Attributes
+		for _, kv := range fields[1:] {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "language":
+				attr.Language = value
+			case "entry":
+				attr.Entry = value
+			case "timeout":
+				if d, err := time.ParseDuration(value); err == nil {
+					attr.Timeout = d
+				}
+			}
+		}
+		set.rules = append(set.rules, attributeRule{re: globToRegexp(glob, false), attr: attr})
+	}
+	return set, nil
+}
+
+// For returns the Attributes that apply to repoRelativePath, and whether any
+// rule matched. Later rules in the file override earlier ones field by
+// field, matching .gitattributes semantics.
+func (s *AttributeSet) For(repoRelativePath string) (Attributes, bool) {
+	cleanPath := filepath.ToSlash(repoRelativePath)
+	var result Attributes
+	matched := false
+	for _, r := range s.rules {
+		if !r.re.MatchString(cleanPath) {
+			continue
+		}
+		matched = true
+		if r.attr.Language != "" {
+			result.Language = r.attr.Language
+		}
+		if r.attr.Entry != "" {
+			result.Entry = r.attr.Entry
+		}
+		if r.attr.Timeout != 0 {
+			result.Timeout = r.attr.Timeout
+		}
+	}
+	return result, matched
+}
@@ -0,0 +1,144 @@
+// Package fileset provides gitignore-style file filtering for the graders,
+// replacing the hand-rolled strings.HasPrefix checks and ad-hoc extension
+// loops that used to be scattered across both binaries. A FileSet merges
+// built-in defaults (the grader's own directories, the configured
+// instructions files) with an optional repo-level .graderignore using the
+// same syntax as .gitignore.
+package fileset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// builtinDefaults are always ignored, regardless of .graderignore contents.
+var builtinDefaults = []string{
+	".github/workflows/**",
+	"correctness-tester/**",
+	"functional-tester/**",
+}
+
+// pattern is one parsed line of a .graderignore file.
+type pattern struct {
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// FileSet matches repo-relative paths against a merged set of ignore
+// patterns.
+type FileSet struct {
+	patterns []pattern
+}
+
+// Load builds a FileSet from the built-in defaults, extraIgnored (e.g. the
+// configured instructions/rubric files), and repoRoot's .graderignore, if
+// present.
+func Load(repoRoot string, extraIgnored []string) (*FileSet, error) {
+	fs := &FileSet{}
+	for _, line := range builtinDefaults {
+		fs.addLine(line)
+	}
+	for _, name := range extraIgnored {
+		if name != "" {
+			fs.addLine(name)
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(repoRoot, ".graderignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fs, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fs.addLine(line)
+	}
+	return fs, nil
+}
+
+func (fs *FileSet) addLine(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return
+	}
+
+	p := pattern{}
+	if strings.HasPrefix(trimmed, "!") {
+		p.negate = true
+		trimmed = trimmed[1:]
+	}
+	if strings.HasSuffix(trimmed, "/") {
+		p.dirOnly = true
+		trimmed = strings.TrimSuffix(trimmed, "/")
+	}
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	p.re = globToRegexp(trimmed, p.dirOnly)
+	fs.patterns = append(fs.patterns, p)
+}
+
+// globToRegexp translates a .gitignore-style glob (supporting "**", "*" and
+// "?") into an anchored regexp matching a repo-relative, slash-separated
+// path. When dirOnly is set (the glob had a trailing "/"), the pattern only
+// matches paths nested under it, not a plain file of the same name.
+func globToRegexp(glob string, dirOnly bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+				// Swallow a following "/" so "**/name" also matches "name" at the repo root.
+				if i+1 < len(runes) && runes[i+1] == '/' {
+					i++
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '.', '+', '(', ')', '|', '^', '$':
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(/.*)?$")
+	}
+	return regexp.MustCompile(b.String())
+}
+
+// Match reports whether repoRelativePath should be excluded from grading.
+func (fs *FileSet) Match(repoRelativePath string) bool {
+	cleanPath := filepath.ToSlash(repoRelativePath)
+	ignored := false
+	for _, p := range fs.patterns {
+		if p.re.MatchString(cleanPath) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Filter returns the subset of paths that Match does not exclude.
+func (fs *FileSet) Filter(paths []string) []string {
+	kept := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !fs.Match(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
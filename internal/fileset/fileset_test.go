@@ -0,0 +1,43 @@
+package fileset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadWithIgnore(t *testing.T, contents string) *FileSet {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".graderignore"), []byte(contents), 0644); err != nil {
+		t.Fatalf("writing .graderignore: %v", err)
+	}
+	fs, err := Load(dir, nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return fs
+}
+
+func TestMatchDirOnlyExcludesFilesUnderDirNotSameNamedFile(t *testing.T) {
+	fs := loadWithIgnore(t, "build/\n")
+
+	if fs.Match("build") {
+		t.Errorf("Match(%q) = true, want false: a plain file named like the directory shouldn't match a dir-only pattern", "build")
+	}
+	if !fs.Match("build/output.bin") {
+		t.Errorf("Match(%q) = false, want true: files nested under a dir-only pattern should match", "build/output.bin")
+	}
+}
+
+func TestMatchOwnGraderDirsAreIgnoredByDefault(t *testing.T) {
+	fs, err := Load(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	for _, p := range []string{"correctness-tester/main.go", "functional-tester/main.go"} {
+		if !fs.Match(p) {
+			t.Errorf("Match(%q) = false, want true", p)
+		}
+	}
+}
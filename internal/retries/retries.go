@@ -0,0 +1,138 @@
+// Package retries wraps flaky calls (GitHub API requests, Gemini generation
+// calls, and similar) with exponential backoff and full jitter, so a
+// transient 502 from api.github.com or a Gemini 429 doesn't take down an
+// entire grader run.
+package retries
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy configures the backoff schedule used by Wait.
+type Policy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter enables the "full jitter" algorithm (sleep = rand(0, min(cap, base*2^attempt)))
+	// described in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+	// Disable it for deterministic tests.
+	Jitter bool
+}
+
+// DefaultPolicy is a reasonable default for GitHub/Gemini API calls.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         true,
+	}
+}
+
+// HTTPError wraps a non-2xx HTTP response so Wait can classify it as
+// retryable or not. Construct one with NewHTTPError from the *http.Response.
+type HTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *HTTPError) Error() string { return e.Err.Error() }
+func (e *HTTPError) Unwrap() error  { return e.Err }
+
+// NewHTTPError builds an HTTPError from resp and err, parsing a Retry-After
+// header expressed either as a number of seconds or an HTTP-date.
+func NewHTTPError(resp *http.Response, err error) *HTTPError {
+	herr := &HTTPError{Err: err}
+	if resp != nil {
+		herr.StatusCode = resp.StatusCode
+		herr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return herr
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Wait calls fn, retrying per policy until it succeeds, a non-retryable
+// error is returned, ctx is cancelled, or MaxAttempts is exhausted.
+func Wait(ctx context.Context, fn func() error, policy Policy) error {
+	var lastErr error
+	backoff := policy.InitialBackoff
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if attempt == policy.MaxAttempts || !retryable(lastErr) {
+			return lastErr
+		}
+
+		sleep := backoff
+		if retryAfter := retryAfterOf(lastErr); retryAfter > 0 {
+			sleep = retryAfter
+		} else if policy.Jitter {
+			sleep = time.Duration(rand.Int63n(int64(sleep) + 1))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+	return lastErr
+}
+
+func retryAfterOf(err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
+
+// retryable classifies err as transient (worth retrying) or permanent.
+func retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return httpErr.StatusCode >= 500
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "RESOURCE_EXHAUSTED") || strings.Contains(msg, "UNAVAILABLE")
+}
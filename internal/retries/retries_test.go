@@ -0,0 +1,67 @@
+package retries
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWaitRetriesOn503ThenSucceeds(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := Policy{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     1 * time.Second,
+		Multiplier:     2,
+		Jitter:         false,
+	}
+
+	var sleeps []time.Duration
+	lastCall := time.Now()
+
+	err := Wait(context.Background(), func() error {
+		now := time.Now()
+		if attempts > 0 {
+			sleeps = append(sleeps, now.Sub(lastCall))
+		}
+		lastCall = now
+
+		resp, doErr := http.Get(server.URL)
+		if doErr != nil {
+			return NewHTTPError(nil, doErr)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return NewHTTPError(resp, &httpStatusError{resp.StatusCode})
+		}
+		return nil
+	}, policy)
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	if len(sleeps) != 2 {
+		t.Fatalf("expected 2 recorded sleeps between 3 attempts, got %d", len(sleeps))
+	}
+	if sleeps[1] <= sleeps[0] {
+		t.Fatalf("expected monotonically increasing sleeps, got %v then %v", sleeps[0], sleeps[1])
+	}
+}
+
+type httpStatusError struct{ code int }
+
+func (e *httpStatusError) Error() string { return http.StatusText(e.code) }
@@ -0,0 +1,220 @@
+// Package sandbox runs student-submitted programs under resource limits so a
+// runaway submission (an infinite loop, a memory leak) can't hang the runner
+// or blow up log storage. It wraps exec.CommandContext with a wall-clock
+// timeout, process-group teardown, CPU/memory/file-size/fd limits on Linux,
+// output capping, and an optional Docker fallback for languages that need
+// stronger isolation than rlimits provide.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultTimeout is used when Limits.Timeout is zero.
+const defaultTimeout = 10 * time.Second
+
+// defaultMaxOutputBytes caps captured stdout/stderr when Limits.MaxOutputBytes is zero.
+const defaultMaxOutputBytes = 64 * 1024
+
+// Limits bounds a single run. Zero values fall back to sane defaults; a
+// zero resource limit (MaxMemoryBytes, MaxCPUSeconds, ...) means "don't set
+// that rlimit".
+type Limits struct {
+	Timeout          time.Duration
+	MaxMemoryBytes   int64 // RLIMIT_AS
+	MaxCPUSeconds    int64 // RLIMIT_CPU
+	MaxFileSizeBytes int64 // RLIMIT_FSIZE
+	MaxOpenFiles     int64 // RLIMIT_NOFILE
+	MaxOutputBytes   int64
+
+	// DockerImage, if set, runs the command inside `docker run --rm
+	// --network none -i <image> <args...>` instead of executing it
+	// directly, for languages whose LanguageConfig opts into the stronger
+	// isolation boundary.
+	DockerImage string
+}
+
+// DefaultLimits returns a Limits with the given timeout (10s when it isn't
+// positive), 256MiB memory, a CPU limit of the timeout's whole seconds plus
+// 5, a 10MiB output file cap, 64 open files, and a 64KiB captured output cap.
+func DefaultLimits(timeout time.Duration) Limits {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return Limits{
+		Timeout:          timeout,
+		MaxMemoryBytes:   256 * 1024 * 1024,
+		MaxCPUSeconds:    int64(timeout.Seconds()) + 5,
+		MaxFileSizeBytes: 10 * 1024 * 1024,
+		MaxOpenFiles:     64,
+		MaxOutputBytes:   defaultMaxOutputBytes,
+	}
+}
+
+// Result is the outcome of a sandboxed Run.
+type Result struct {
+	Stdout    string
+	Stderr    string
+	Truncated bool
+	TimedOut  bool
+	OOMKilled bool
+	Err       error
+}
+
+// capped is a bytes.Buffer that stops accepting writes past limit and
+// records that truncation happened instead of returning an error (the
+// child process shouldn't see write failures just because it was chatty).
+type capped struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *capped) Write(p []byte) (int, error) {
+	if c.limit <= 0 || c.buf.Len() >= c.limit {
+		c.truncated = true
+		return len(p), nil
+	}
+	if c.buf.Len()+len(p) > c.limit {
+		c.buf.Write(p[:c.limit-c.buf.Len()])
+		c.truncated = true
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+// Run executes name/args (or, when limits.DockerImage is set, a `docker run`
+// invocation wrapping them) with stdin fed to the process, enforcing limits.
+func Run(ctx context.Context, limits Limits, stdin string, name string, args []string) (*Result, error) {
+	if limits.Timeout <= 0 {
+		limits.Timeout = defaultTimeout
+	}
+	if limits.MaxOutputBytes <= 0 {
+		limits.MaxOutputBytes = defaultMaxOutputBytes
+	}
+
+	runName, runArgs := name, args
+	if limits.DockerImage != "" {
+		runName, runArgs = dockerCommand(limits, name, args)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(timeoutCtx, runName, runArgs...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var stdout, stderr capped
+	stdout.limit = int(limits.MaxOutputBytes)
+	stderr.limit = int(limits.MaxOutputBytes)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("sandbox: starting command: %w", err)
+	}
+
+	if limits.DockerImage == "" {
+		applyRlimits(cmd.Process.Pid, limits)
+	}
+
+	killed := make(chan struct{})
+	go func() {
+		select {
+		case <-timeoutCtx.Done():
+			// Kill the whole process group so a forking submission can't
+			// outlive the timeout.
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-killed:
+		}
+	}()
+
+	runErr := cmd.Wait()
+	close(killed)
+
+	result := &Result{
+		Stdout:    stdout.buf.String(),
+		Stderr:    stderr.buf.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+
+	if timeoutCtx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		return result, nil
+	}
+	if runErr != nil {
+		if isLikelyOOM(runErr, limits.MaxMemoryBytes) {
+			result.OOMKilled = true
+			return result, nil
+		}
+		result.Err = runErr
+	}
+	return result, nil
+}
+
+func killSignal(err error) (syscall.Signal, bool) {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return status.Signal(), true
+}
+
+// isLikelyOOM reports whether runErr looks like the process died from
+// exhausting maxMemoryBytes (RLIMIT_AS), rather than an ordinary SIGSEGV or
+// SIGBUS from a student bug (null deref, bad array access) that has nothing
+// to do with the memory cap. A crash signal alone isn't evidence of OOM: it
+// only counts when a memory limit was actually configured and the process's
+// own peak RSS (from its rusage) was already close to that limit when it
+// died.
+func isLikelyOOM(err error, maxMemoryBytes int64) bool {
+	if maxMemoryBytes <= 0 {
+		return false
+	}
+	sig, ok := killSignal(err)
+	if !ok || (sig != syscall.SIGSEGV && sig != syscall.SIGBUS) {
+		return false
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	rusage, ok := exitErr.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return false
+	}
+	// Maxrss is reported in KiB on Linux.
+	peakRSS := rusage.Maxrss * 1024
+	return peakRSS >= maxMemoryBytes*9/10
+}
+
+// dockerCommand builds a `docker run` invocation that runs name/args inside
+// image with a comparable resource envelope to the rlimit path.
+func dockerCommand(limits Limits, name string, args []string) (string, []string) {
+	memMB := limits.MaxMemoryBytes / (1024 * 1024)
+	if memMB <= 0 {
+		memMB = 256
+	}
+	dockerArgs := []string{
+		"run", "--rm",
+		"--network", "none",
+		"--memory", strconv.FormatInt(memMB, 10) + "m",
+		"--cpus", "0.5",
+		"-i", limits.DockerImage,
+		name,
+	}
+	dockerArgs = append(dockerArgs, args...)
+	return "docker", dockerArgs
+}
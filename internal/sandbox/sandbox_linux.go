@@ -0,0 +1,28 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"log"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyRlimits sets RLIMIT_AS/CPU/FSIZE/NOFILE on pid after it has been
+// spawned. Failures are logged, not fatal: a submission running without one
+// rlimit is still better than failing the whole grading run over it.
+func applyRlimits(pid int, limits Limits) {
+	set := func(name string, resource int, value int64) {
+		if value <= 0 {
+			return
+		}
+		rlimit := unix.Rlimit{Cur: uint64(value), Max: uint64(value)}
+		if err := unix.Prlimit(pid, resource, &rlimit, nil); err != nil {
+			log.Printf("sandbox: failed to set %s: %v", name, err)
+		}
+	}
+	set("RLIMIT_AS", unix.RLIMIT_AS, limits.MaxMemoryBytes)
+	set("RLIMIT_CPU", unix.RLIMIT_CPU, limits.MaxCPUSeconds)
+	set("RLIMIT_FSIZE", unix.RLIMIT_FSIZE, limits.MaxFileSizeBytes)
+	set("RLIMIT_NOFILE", unix.RLIMIT_NOFILE, limits.MaxOpenFiles)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package sandbox
+
+// applyRlimits is a no-op outside Linux: Prlimit isn't portable, so on other
+// platforms the timeout and output caps are the only enforced limits.
+func applyRlimits(pid int, limits Limits) {}